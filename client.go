@@ -1,6 +1,7 @@
 package simrpc
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"net"
 	"simrpc/codec"
 	"sync"
+	"time"
 )
 
 // 只支持形如:
@@ -87,6 +89,7 @@ func (c *Client) removeCall(seq uint64) *Call {
 	if !ok {
 		return nil
 	}
+	delete(c.pending, seq)
 	return call
 }
 
@@ -188,15 +191,22 @@ func (c *Client) Go(serviceMethod string, args, reply any, done chan *Call) *Cal
 }
 
 // Call rpc同步调用
-func (c *Client) Call(serviceMethod string, args, reply any) error {
-	call := <-c.Go(serviceMethod, args, reply, make(chan *Call, 1)).Done
-	return call.Error
+// ctx超时或被取消时,会主动把对应的call从pending中摘除并立刻返回
+func (c *Client) Call(ctx context.Context, serviceMethod string, args, reply any) error {
+	call := c.Go(serviceMethod, args, reply, make(chan *Call, 1))
+	select {
+	case <-ctx.Done():
+		c.removeCall(call.Seq)
+		return fmt.Errorf("rpc client: call failed: %w", ctx.Err())
+	case call := <-call.Done:
+		return call.Error
+	}
 }
 
 // NewClient 创建Client实例
 // 会完成option部分的发送以及接收协程的开启
 func NewClient(conn net.Conn, option *Option) (*Client, error) {
-	fn, ok := codec.NewCodecFuncMap[option.CodeType]
+	fn, ok := codec.Lookup(option.CodeType)
 	if !ok {
 		log.Printf("rpc client: invalid code type: %v\n", option.CodeType)
 		err := fmt.Errorf("rpc client: invalid code type: %v", option.CodeType)
@@ -237,7 +247,7 @@ func Dial(network, addr string, options ...*Option) (client *Client, err error)
 		return nil, err
 	}
 
-	conn, err := net.Dial(network, addr)
+	conn, err := net.DialTimeout(network, addr, option.ConnectTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -249,7 +259,38 @@ func Dial(network, addr string, options ...*Option) (client *Client, err error)
 		}
 	}()
 
-	return NewClient(conn, option)
+	return dialTimeout(NewClient, conn, option)
+}
+
+// newClientFunc 建立连接后把conn包装成Client的构造方式
+// Dial/DialHTTP各有自己的handshake,但都复用dialTimeout来控制ConnectTimeout
+type newClientFunc func(conn net.Conn, option *Option) (*Client, error)
+
+type clientResult struct {
+	client *Client
+	err    error
+}
+
+// dialTimeout 在option.ConnectTimeout内等待f(conn, option)完成,超时则返回错误
+// 由于Option/handshake本身可能因为网络问题挂住,这里用channel race避免调用方被无限阻塞
+func dialTimeout(f newClientFunc, conn net.Conn, option *Option) (client *Client, err error) {
+	ch := make(chan clientResult, 1)
+	go func() {
+		c, e := f(conn, option)
+		ch <- clientResult{client: c, err: e}
+	}()
+
+	if option.ConnectTimeout == 0 {
+		result := <-ch
+		return result.client, result.err
+	}
+
+	select {
+	case <-time.After(option.ConnectTimeout):
+		return nil, fmt.Errorf("rpc client: connect timeout: expect within %s", option.ConnectTimeout)
+	case result := <-ch:
+		return result.client, result.err
+	}
 }
 
 // 检查是否需要使用DefaultOption
@@ -1,6 +1,9 @@
 package codec
 
-import "io"
+import (
+	"io"
+	"sync"
+)
 
 // 一个完整request(请求)由一个header和一个body组成
 // request的编码方式由建立连接时,连接首部的option决定
@@ -29,14 +32,35 @@ type Type string
 
 // 列举可选的body编解码方式
 const (
-	GobType  = "gob"
-	JsonType = "json"
+	GobType      = "gob"
+	JsonType     = "json"
+	ProtobufType = "protobuf"
 )
 
-var NewCodecFuncMap map[Type]NewCodecFunc
+var (
+	newCodecFuncMapMu sync.Mutex
+	newCodecFuncMap   map[Type]NewCodecFunc
+)
 
 func init() {
-	NewCodecFuncMap = make(map[Type]NewCodecFunc)
-	NewCodecFuncMap[GobType] = NewGobCodec
-	NewCodecFuncMap[JsonType] = NewJsonCodec
+	newCodecFuncMap = make(map[Type]NewCodecFunc)
+	Register(GobType, NewGobCodec)
+	Register(JsonType, NewJsonCodec)
+	Register(ProtobufType, NewProtobufCodec)
+}
+
+// Register 注册一种body编解码方式
+// 下游包也可以通过它注册自己的Codec实现,而不需要直接改这个map
+func Register(t Type, fn NewCodecFunc) {
+	newCodecFuncMapMu.Lock()
+	defer newCodecFuncMapMu.Unlock()
+	newCodecFuncMap[t] = fn
+}
+
+// Lookup 按Type查找对应的Codec构造函数
+func Lookup(t Type) (NewCodecFunc, bool) {
+	newCodecFuncMapMu.Lock()
+	defer newCodecFuncMapMu.Unlock()
+	fn, ok := newCodecFuncMap[t]
+	return fn, ok
 }
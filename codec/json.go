@@ -41,18 +41,20 @@ func (g JsonCodec) ReadBody(body any) error {
 	return g.dec.Decode(body)
 }
 
-func (g JsonCodec) Write(header *Header, body any) error {
+func (g JsonCodec) Write(header *Header, body any) (err error) {
 	defer func() {
 		_ = g.buf.Flush()
-		_ = g.Close()
+		if err != nil {
+			_ = g.Close()
+		}
 	}()
 
-	if err := g.enc.Encode(header); err != nil {
+	if err = g.enc.Encode(header); err != nil {
 		log.Printf("rpc encoding header error: %v\n", err)
 		return err
 	}
 
-	if err := g.enc.Encode(body); err != nil {
+	if err = g.enc.Encode(body); err != nil {
 		log.Printf("rpc encoding body error: %v\n", err)
 		return err
 	}
@@ -1,6 +1,9 @@
-package simpleRPC
+package simrpc
 
-import "simrpc/codec"
+import (
+	"simrpc/codec"
+	"time"
+)
 
 // 与rpc连接相关的结构定义
 
@@ -15,9 +18,18 @@ const MagicNumber = 0x3bef5c
 type Option struct {
 	MagicNumber int
 	CodeType    codec.Type
+
+	ConnectTimeout time.Duration // 建立连接(含Option/handshake)的超时时间,0表示不限制
+	HandleTimeout  time.Duration // 单次rpc调用处理的超时时间,0表示不限制
+
+	// MaxRequestSize 单个request(header+body)允许读取的最大字节数,0表示不限制
+	// 超限发生在读body阶段时,server会给这次调用回一个带Error的正常response,连接本身还能继续用
+	// 但如果超限发生在还没读完header阶段,帧结构已经无法恢复对齐,这条连接会被直接断开
+	MaxRequestSize int
 }
 
 var DefaultOption = &Option{
-	MagicNumber: MagicNumber,
-	CodeType:    codec.GobType,
+	MagicNumber:    MagicNumber,
+	CodeType:       codec.GobType,
+	ConnectTimeout: time.Second * 10,
 }
@@ -0,0 +1,57 @@
+package simrpc
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+)
+
+const debugText = `<html>
+	<body>
+	<title>simRPC Services</title>
+	{{range .}}
+	<hr>
+	Service {{.Name}}
+	<hr>
+		<table>
+		<th align=center>Method</th><th align=center>Calls</th>
+		{{range $name, $mtype := .Method}}
+			<tr>
+			<td align=left font=fixed>{{$name}}({{$mtype.ArgType}}, {{$mtype.ReplyType}}) error</td>
+			<td align=center>{{$mtype.NumCalls}}</td>
+			</tr>
+		{{end}}
+		</table>
+	{{end}}
+	</body>
+	</html>`
+
+var debugTemplate = template.Must(template.New("RPC debug").Parse(debugText))
+
+// debugHTTP 以HTML形式展示server上已注册的service/method及调用次数
+type debugHTTP struct {
+	*Server
+}
+
+type debugService struct {
+	Name   string
+	Method map[string]*methodType
+}
+
+func (s debugHTTP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var services []debugService
+
+	s.serviceMap.Range(func(key, value any) bool {
+		svc := value.(*service)
+		services = append(services, debugService{
+			Name:   svc.name,
+			Method: svc.method,
+		})
+		return true
+	})
+
+	err := debugTemplate.Execute(w, services)
+	if err != nil {
+		log.Printf("rpc: error executing template: %v\n", err)
+	}
+}
@@ -0,0 +1,96 @@
+package simrpc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+)
+
+// DefaultRPCPath rpc请求走HTTP CONNECT时默认的访问路径
+const DefaultRPCPath = "/_simrpc_"
+
+// DefaultDebugPath 查看服务注册情况的debug页面路径
+const DefaultDebugPath = "/debug/simrpc"
+
+// connected 握手成功时回写给客户端的状态行
+const connected = "200 Connected to simRPC"
+
+// ServeHTTP 实现http.Handler接口
+// 只接受CONNECT方法,握手成功后把连接交给serveConn继续走rpc协议
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		_, _ = fmt.Fprintf(w, "405 must CONNECT\n")
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		log.Printf("rpc server: connection doesn't support hijacking\n")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		log.Printf("rpc server: hijacking %s error: %v\n", r.RemoteAddr, err)
+		return
+	}
+
+	_, _ = conn.Write([]byte("HTTP/1.0 " + connected + "\n\n"))
+	s.serveConn(conn)
+}
+
+// HandleHTTP 将rpc消息注册到http.DefaultServeMux上
+// 用户可以另起监听端口或复用已有的http server
+func (s *Server) HandleHTTP() {
+	http.Handle(DefaultRPCPath, s)
+	http.Handle(DefaultDebugPath, debugHTTP{s})
+}
+
+// HandleHTTP 为DefaultServer注册HTTP handler
+func HandleHTTP() {
+	DefaultServer.HandleHTTP()
+}
+
+// DialHTTP 通过HTTP CONNECT先建立隧道,再按rpc协议创建client
+// connects to an HTTP RPC server at the specified network address
+// listening on the default HTTP RPC path
+func DialHTTP(network, addr string, opts ...*Option) (client *Client, err error) {
+	option, err := parseOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialTimeout(network, addr, option.ConnectTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if client == nil {
+			_ = conn.Close()
+		}
+	}()
+
+	return dialTimeout(newHTTPClient, conn, option)
+}
+
+// newHTTPClient 完成CONNECT握手后再复用NewClient走正常的Option/Codec流程
+func newHTTPClient(conn net.Conn, option *Option) (*Client, error) {
+	_, _ = fmt.Fprintf(conn, "CONNECT %s HTTP/1.0\n\n", DefaultRPCPath)
+
+	// 在切换到rpc协议前需要一个成功的HTTP response
+	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: http.MethodConnect})
+	if err == nil && resp.Status == connected {
+		return NewClient(conn, option)
+	}
+	if err == nil {
+		err = errors.New("unexpected HTTP response: " + resp.Status)
+	}
+	return nil, err
+}
@@ -0,0 +1,58 @@
+package simrpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// Adder 供集成测试注册的服务
+type Adder struct{}
+
+func (a *Adder) Add(args [2]int, reply *int) error {
+	*reply = args[0] + args[1]
+	return nil
+}
+
+// TestCallTwiceOnOneConnection 验证同一条连接上可以连续完成多次rpc调用
+// 曾经GobCodec/JsonCodec在Write之后无条件关闭底层conn,导致第一次调用发完
+// 请求就把连接关掉了,这里用两次调用来覆盖这种回归
+func TestCallTwiceOnOneConnection(t *testing.T) {
+	server := NewServer()
+	if err := server.Register(&Adder{}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	go server.Accept(listener)
+
+	client, err := Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var reply int
+	if err := client.Call(ctx, "Adder.Add", [2]int{1, 2}, &reply); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	if reply != 3 {
+		t.Fatalf("first call: got reply %d, want 3", reply)
+	}
+
+	if err := client.Call(ctx, "Adder.Add", [2]int{4, 5}, &reply); err != nil {
+		t.Fatalf("second call on the same connection: %v", err)
+	}
+	if reply != 9 {
+		t.Fatalf("second call: got reply %d, want 9", reply)
+	}
+}
@@ -0,0 +1,129 @@
+package registry
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultPath 注册中心默认挂载的HTTP路径
+const defaultPath = "/_simrpc_/registry"
+
+// defaultTimeout 默认多久没收到心跳就认为服务已经下线
+const defaultTimeout = time.Minute * 5
+
+// ServerItem 记录一个服务地址以及最近一次上报的时间
+type ServerItem struct {
+	Addr  string
+	start time.Time
+}
+
+// Registry 一个简单的注册中心,支持服务注册/心跳/过期踢除
+type Registry struct {
+	timeout time.Duration
+	mu      sync.Mutex
+	servers map[string]*ServerItem
+}
+
+// New 创建一个指定超时时间的Registry
+func New(timeout time.Duration) *Registry {
+	return &Registry{
+		servers: make(map[string]*ServerItem),
+		timeout: timeout,
+	}
+}
+
+// DefaultRegistry 使用默认超时时间的Registry实例
+var DefaultRegistry = New(defaultTimeout)
+
+// putServer 注册一个新地址,或者给已存在的地址续一次心跳
+func (r *Registry) putServer(addr string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := r.servers[addr]
+	if s == nil {
+		r.servers[addr] = &ServerItem{Addr: addr, start: time.Now()}
+		return
+	}
+	s.start = time.Now() // 续约
+}
+
+// aliveServers 返回所有未过期的服务地址,顺带清理掉过期的
+func (r *Registry) aliveServers() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var alive []string
+	for addr, s := range r.servers {
+		if r.timeout == 0 || s.start.Add(r.timeout).After(time.Now()) {
+			alive = append(alive, addr)
+		} else {
+			delete(r.servers, addr)
+		}
+	}
+	sort.Strings(alive)
+	return alive
+}
+
+// ServeHTTP 实现http.Handler
+// GET返回存活服务列表,POST注册/续约一个服务地址
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		w.Header().Set("X-Simrpc-Servers", strings.Join(r.aliveServers(), ","))
+	case http.MethodPost:
+		addr := req.Header.Get("X-Simrpc-Server")
+		if addr == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.putServer(addr)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleHTTP 把registry挂载到http.DefaultServeMux的path上
+func (r *Registry) HandleHTTP(path string) {
+	http.Handle(path, r)
+}
+
+// HandleHTTP 把DefaultRegistry挂载到默认路径
+func HandleHTTP() {
+	DefaultRegistry.HandleHTTP(defaultPath)
+}
+
+// Heartbeat 让一个服务端周期性地向registry发送心跳,保持自己处于存活状态
+// duration<=0时取默认周期(比过期时间稍短,留出网络延迟的余量)
+func Heartbeat(registry, addr string, duration time.Duration) {
+	if duration <= 0 {
+		duration = defaultTimeout - time.Minute
+	}
+
+	_ = sendHeartbeat(registry, addr)
+	go func() {
+		ticker := time.NewTicker(duration)
+		for range ticker.C {
+			// 单次心跳失败不应永久停止上报,错误已在sendHeartbeat中记录,继续重试
+			_ = sendHeartbeat(registry, addr)
+		}
+	}()
+}
+
+func sendHeartbeat(registry, addr string) error {
+	log.Printf("rpc server: %s send heartbeat to registry %s\n", addr, registry)
+
+	httpClient := &http.Client{}
+	req, _ := http.NewRequest(http.MethodPost, registry, nil)
+	req.Header.Set("X-Simrpc-Server", addr)
+
+	if _, err := httpClient.Do(req); err != nil {
+		log.Printf("rpc server: heartbeat error: %v\n", err)
+		return err
+	}
+	return nil
+}
@@ -1,18 +1,24 @@
 package simrpc
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"reflect"
 	"simrpc/codec"
+	"strings"
 	"sync"
+	"time"
 )
 
 // Server rpc服务端
-type Server struct{}
+type Server struct {
+	serviceMap sync.Map // serviceName -> *service
+}
 
 func NewServer() *Server {
 	return &Server{}
@@ -20,6 +26,50 @@ func NewServer() *Server {
 
 var DefaultServer = NewServer()
 
+// Register 在server中注册rcvr,注册名取rcvr的类型名
+func (s *Server) Register(rcvr any) error {
+	return s.RegisterName(reflect.Indirect(reflect.ValueOf(rcvr)).Type().Name(), rcvr)
+}
+
+// RegisterName 以name为名在server中注册rcvr
+// rcvr上形如func (t *T) Method(argType T1, replyType *T2) error的方法都会被记录下来
+func (s *Server) RegisterName(name string, rcvr any) error {
+	svc := newService(rcvr)
+	svc.name = name
+	if _, dup := s.serviceMap.LoadOrStore(name, svc); dup {
+		return errors.New("rpc server: service already defined: " + name)
+	}
+	return nil
+}
+
+// Register 在默认server中注册rcvr
+func Register(rcvr any) error {
+	return DefaultServer.Register(rcvr)
+}
+
+// findService 依据"Service.Method"从serviceMap中找到对应的service和methodType
+func (s *Server) findService(serviceMethod string) (svc *service, mtype *methodType, err error) {
+	dot := strings.LastIndex(serviceMethod, ".")
+	if dot < 0 {
+		err = errors.New("rpc server: service/method request ill-formed: " + serviceMethod)
+		return
+	}
+
+	serviceName, methodName := serviceMethod[:dot], serviceMethod[dot+1:]
+	svci, ok := s.serviceMap.Load(serviceName)
+	if !ok {
+		err = errors.New("rpc server: can't find service: " + serviceName)
+		return
+	}
+	svc = svci.(*service)
+
+	mtype, ok = svc.method[methodName]
+	if !ok {
+		err = errors.New("rpc server: can't find method: " + methodName)
+	}
+	return
+}
+
 // Accept 使用默认server进行连接建立
 func Accept(listener net.Listener) {
 	DefaultServer.Accept(listener)
@@ -43,31 +93,75 @@ func (s *Server) serveConn(conn io.ReadWriteCloser) {
 		_ = conn.Close()
 	}()
 
+	// json.Decoder自带缓冲,一次Read可能把option后面紧跟着的请求字节也一并读了进来;
+	// Decode返回后这些字节仍停留在decoder内部,用dec.Buffered()找回,避免连接快的时候
+	// (option和第一个请求前后脚写入同一个TCP包里)这部分字节被直接丢弃导致后续读取永远卡住
+	dec := json.NewDecoder(conn)
 	option := &Option{}
-	err := json.NewDecoder(conn).Decode(option)
+	err := dec.Decode(option)
 	if err != nil {
 		log.Printf("rpc server: option decode error: %v\n", err)
 		return
 	}
+	reader := io.MultiReader(trimLeadingJSONSpace(dec.Buffered()), conn)
 
 	if option.MagicNumber != MagicNumber {
 		log.Printf("rpc server: invalid magic number: %v\n", option.MagicNumber)
 		return
 	}
 
-	fn, ok := codec.NewCodecFuncMap[option.CodeType]
+	fn, ok := codec.Lookup(option.CodeType)
 	if !ok {
 		log.Printf("rpc server: invalid code type: %v\n", option.CodeType)
 		return
 	}
 
-	s.serveCodec(fn(conn))
+	var limit *io.LimitedReader
+	if option.MaxRequestSize > 0 {
+		limit = &io.LimitedReader{R: reader, N: int64(option.MaxRequestSize)}
+		reader = limit
+	}
+	rwc := &limitedReadWriteCloser{
+		Reader: reader,
+		Writer: conn,
+		Closer: conn,
+	}
+
+	s.serveCodec(fn(rwc), option, limit)
+}
+
+// trimLeadingJSONSpace 跳过r开头的JSON空白字符(json.Encoder.Encode每次都会在值后面补一个'\n'，
+// Decode不需要消费它,但它已经被读进了decoder的缓冲区,不跳过的话会被误当成后续协议的第一个字节)
+func trimLeadingJSONSpace(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return br
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		default:
+			_ = br.UnreadByte()
+			return br
+		}
+	}
+}
+
+// limitedReadWriteCloser 拼出codec需要的io.ReadWriteCloser:读走option时已经预读的字节
+// 需要继续从reader读,写入和关闭则还是直接作用在conn上;MaxRequestSize>0时Reader会换成
+// io.LimitedReader,限制的是单个request的大小(每个request开始前重新充满),而非整条连接的累计读取量
+type limitedReadWriteCloser struct {
+	io.Reader
+	io.Writer
+	io.Closer
 }
 
 var invalidReply = struct{}{}
 
 // 处理后续的一连串request(header + body)部分
-func (s *Server) serveCodec(cc codec.Codec) {
+func (s *Server) serveCodec(cc codec.Codec, opt *Option, limit *io.LimitedReader) {
 	defer func() {
 		_ = cc.Close()
 	}()
@@ -76,6 +170,11 @@ func (s *Server) serveCodec(cc codec.Codec) {
 	wg := &sync.WaitGroup{} // 用于等待所有request都被处理完
 
 	for {
+		if limit != nil {
+			// 每个request开始前重新充满额度,MaxRequestSize限制的是单个request而不是整条连接
+			limit.N = int64(opt.MaxRequestSize)
+		}
+
 		req, err := s.readRequest(cc)
 		if err != nil {
 			if req == nil {
@@ -88,32 +187,48 @@ func (s *Server) serveCodec(cc codec.Codec) {
 			continue
 		}
 		wg.Add(1)
-		go s.handleRequest(cc, req, sending, wg)
+		go s.handleRequest(cc, req, sending, wg, opt.HandleTimeout)
 	}
 	wg.Wait()
 }
 
+// request 一次rpc调用的完整上下文
+type request struct {
+	header       *codec.Header
+	argv, replyv reflect.Value
+	mtype        *methodType
+	svc          *service
+}
+
 func (s *Server) readRequest(cc codec.Codec) (*request, error) {
 	h := codec.Header{}
 	err := cc.ReadHeader(&h)
 	if err != nil {
+		// header本身没读完整(不管是连接出错还是撞上了MaxRequestSize),
+		// 之后的字节在协议里就对不上帧了,没法再按请求级别恢复,只能把整条连接断掉
 		log.Printf("rpc server: read header fail: %v\n", err)
 		return nil, err
 	}
 
-	req := &request{
-		header: &h,
-		argv:   reflect.Value{},
-		reply:  reflect.Value{},
+	req := &request{header: &h}
+	req.svc, req.mtype, err = s.findService(h.ServiceMethod)
+	if err != nil {
+		return req, err
 	}
 
-	// 还没有定义request body具体的类型,先用string代替
-	req.argv = reflect.New(reflect.TypeOf(""))
+	req.argv = req.mtype.newArgv()
+	req.replyv = req.mtype.newReplyv()
 
-	err = cc.ReadBody(req.argv.Interface())
+	// ReadBody需要一个指针,argv本身可能不是指针
+	argvi := req.argv.Interface()
+	if req.argv.Kind() != reflect.Ptr {
+		argvi = req.argv.Addr().Interface()
+	}
+
+	err = cc.ReadBody(argvi)
 	if err != nil {
 		log.Printf("rpc server: read body fail: %v\n", err)
-		return nil, err
+		return req, err
 	}
 
 	return req, nil
@@ -129,16 +244,40 @@ func (s *Server) sendResponse(cc codec.Codec, header *codec.Header, body any, se
 	}
 }
 
-func (s *Server) handleRequest(cc codec.Codec, req *request, sending *sync.Mutex, wg *sync.WaitGroup) {
-	// 这里需要做的是依据header中指定的ServiceMethod找到并调用相应的方法
-	// 然后把返回值放到body里发送回客户端
-
+func (s *Server) handleRequest(cc codec.Codec, req *request, sending *sync.Mutex, wg *sync.WaitGroup, timeout time.Duration) {
 	defer wg.Done()
 
-	// 还没有确定response body的具体类型,先用string代替
-	req.reply = reflect.ValueOf(fmt.Sprintf("rpc reply: %v", req.header.Seq))
+	called := make(chan struct{}, 1)
+	sent := make(chan struct{}, 1)
+	once := &sync.Once{} // 保证超时与正常返回只有一个能真正发送response
+
+	go func() {
+		err := req.svc.call(req.mtype, req.argv, req.replyv)
+		called <- struct{}{}
+		once.Do(func() {
+			if err != nil {
+				req.header.Error = err.Error()
+				s.sendResponse(cc, req.header, invalidReply, sending)
+			} else {
+				s.sendResponse(cc, req.header, req.replyv.Interface(), sending)
+			}
+		})
+		sent <- struct{}{}
+	}()
+
+	if timeout == 0 {
+		<-called
+		<-sent
+		return
+	}
 
-	// 先只实现一个服务端的简单打印
-	log.Printf("[rpc server] get request: %v - %v\n", req.header, req.argv.Elem())
-	s.sendResponse(cc, req.header, req.reply.Interface(), sending)
+	select {
+	case <-time.After(timeout):
+		once.Do(func() {
+			req.header.Error = fmt.Sprintf("rpc server: request handle timeout: expect within %s", timeout)
+			s.sendResponse(cc, req.header, invalidReply, sending)
+		})
+	case <-called:
+		<-sent
+	}
 }
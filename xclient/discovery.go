@@ -0,0 +1,95 @@
+package xclient
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// SelectMode 负载均衡策略
+type SelectMode int
+
+const (
+	RandomSelect     SelectMode = iota // 随机选择一个
+	RoundRobinSelect                   // 轮询选择
+)
+
+// Discovery 服务发现接口
+// 解耦XClient与具体的服务列表来源(静态列表/注册中心等)
+type Discovery interface {
+	Refresh() error // 从远端刷新服务列表
+	Update(servers []string) error
+	Get(mode SelectMode) (string, error)
+	GetAll() ([]string, error)
+}
+
+// MultiServersDiscovery 一份静态的服务地址列表,不需要注册中心支持
+type MultiServersDiscovery struct {
+	r       *rand.Rand
+	mu      sync.RWMutex
+	servers []string
+	index   int // 记录轮询到的位置
+}
+
+var _ Discovery = (*MultiServersDiscovery)(nil)
+
+// NewMultiServerDiscovery 创建一个MultiServersDiscovery实例
+func NewMultiServerDiscovery(servers []string) *MultiServersDiscovery {
+	d := &MultiServersDiscovery{
+		servers: servers,
+		r:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	// 避免每次从0开始轮询
+	n := len(servers)
+	if n == 0 {
+		n = 1
+	}
+	d.index = d.r.Intn(n)
+	return d
+}
+
+// Refresh 静态列表没有远端可刷新,直接返回nil
+func (d *MultiServersDiscovery) Refresh() error {
+	return nil
+}
+
+// Update 更新服务地址列表
+func (d *MultiServersDiscovery) Update(servers []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.servers = servers
+	return nil
+}
+
+// Get 按mode选出一个服务地址
+func (d *MultiServersDiscovery) Get(mode SelectMode) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	n := len(d.servers)
+	if n == 0 {
+		return "", errors.New("rpc discovery: no available servers")
+	}
+
+	switch mode {
+	case RandomSelect:
+		return d.servers[d.r.Intn(n)], nil
+	case RoundRobinSelect:
+		server := d.servers[d.index%n]
+		d.index = (d.index + 1) % n
+		return server, nil
+	default:
+		return "", errors.New("rpc discovery: not supported select mode")
+	}
+}
+
+// GetAll 返回所有服务地址的拷贝
+func (d *MultiServersDiscovery) GetAll() ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	servers := make([]string, len(d.servers))
+	copy(servers, d.servers)
+	return servers, nil
+}
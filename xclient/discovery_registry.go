@@ -0,0 +1,94 @@
+package xclient
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RegistryDiscovery 通过注册中心周期性拉取服务列表
+// 内嵌MultiServersDiscovery复用其Get/GetAll/负载均衡逻辑
+type RegistryDiscovery struct {
+	*MultiServersDiscovery
+	registry   string        // 注册中心地址
+	timeout    time.Duration // 服务列表的过期时间,超过这个时间需要重新Refresh
+	lastUpdate time.Time     // 上一次更新服务列表的时间
+	mu         sync.Mutex
+}
+
+// defaultUpdateTimeout 默认多久没刷新就视为过期
+const defaultUpdateTimeout = time.Second * 10
+
+// NewRegistryDiscovery 创建一个RegistryDiscovery实例
+// timeout<=0时采用defaultUpdateTimeout
+func NewRegistryDiscovery(registerAddr string, timeout time.Duration) *RegistryDiscovery {
+	if timeout <= 0 {
+		timeout = defaultUpdateTimeout
+	}
+	return &RegistryDiscovery{
+		MultiServersDiscovery: NewMultiServerDiscovery(make([]string, 0)),
+		registry:              registerAddr,
+		timeout:               timeout,
+	}
+}
+
+// Update 主动指定服务列表,同时刷新lastUpdate
+// servers的读写交给内嵌的MultiServersDiscovery,这里只负责自身的lastUpdate
+func (d *RegistryDiscovery) Update(servers []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.MultiServersDiscovery.Update(servers); err != nil {
+		return err
+	}
+	d.lastUpdate = time.Now()
+	return nil
+}
+
+// Refresh 向注册中心请求最新的服务列表
+func (d *RegistryDiscovery) Refresh() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.lastUpdate.Add(d.timeout).After(time.Now()) {
+		return nil
+	}
+
+	log.Printf("rpc registry: refresh servers from registry %s\n", d.registry)
+
+	resp, err := http.Get(d.registry)
+	if err != nil {
+		log.Printf("rpc registry: refresh error: %v\n", err)
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	servers := make([]string, 0)
+	for _, server := range strings.Split(resp.Header.Get("X-Simrpc-Servers"), ",") {
+		if strings.TrimSpace(server) != "" {
+			servers = append(servers, strings.TrimSpace(server))
+		}
+	}
+	if err := d.MultiServersDiscovery.Update(servers); err != nil {
+		return err
+	}
+	d.lastUpdate = time.Now()
+	return nil
+}
+
+// Get 选取服务地址前先确保列表没有过期
+func (d *RegistryDiscovery) Get(mode SelectMode) (string, error) {
+	if err := d.Refresh(); err != nil {
+		return "", err
+	}
+	return d.MultiServersDiscovery.Get(mode)
+}
+
+// GetAll 返回全部服务地址前先确保列表没有过期
+func (d *RegistryDiscovery) GetAll() ([]string, error) {
+	if err := d.Refresh(); err != nil {
+		return nil, err
+	}
+	return d.MultiServersDiscovery.GetAll()
+}
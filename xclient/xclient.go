@@ -0,0 +1,177 @@
+package xclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"reflect"
+	"simrpc"
+	"sync"
+)
+
+// XClient 支持服务发现与负载均衡的rpc客户端
+// 对上层暴露与simrpc.Client一致的Call/Go,同时多了一个可以广播到所有服务端的Broadcast
+type XClient struct {
+	d       Discovery
+	mode    SelectMode
+	opt     *simrpc.Option
+	mu      sync.Mutex
+	clients map[string]*simrpc.Client // 复用已经建立好的连接,avoid重复Dial
+}
+
+var _ io.Closer = (*XClient)(nil)
+
+// NewXClient 创建一个XClient实例
+func NewXClient(d Discovery, mode SelectMode, opt *simrpc.Option) *XClient {
+	return &XClient{
+		d:       d,
+		mode:    mode,
+		opt:     opt,
+		clients: make(map[string]*simrpc.Client),
+	}
+}
+
+// Close 关闭所有缓存的连接
+func (xc *XClient) Close() error {
+	xc.mu.Lock()
+	defer xc.mu.Unlock()
+
+	for key, client := range xc.clients {
+		_ = client.Close()
+		delete(xc.clients, key)
+	}
+	return nil
+}
+
+// dial 优先复用已有连接,不可用时重新Dial并替换缓存
+func (xc *XClient) dial(rpcAddr string) (*simrpc.Client, error) {
+	xc.mu.Lock()
+	defer xc.mu.Unlock()
+
+	client, ok := xc.clients[rpcAddr]
+	if ok && !client.IsAvailable() {
+		_ = client.Close()
+		delete(xc.clients, rpcAddr)
+		client = nil
+		ok = false
+	}
+
+	if !ok {
+		var err error
+		client, err = simrpc.Dial("tcp", rpcAddr, xc.opt)
+		if err != nil {
+			return nil, err
+		}
+		xc.clients[rpcAddr] = client
+	}
+	return client, nil
+}
+
+func (xc *XClient) call(ctx context.Context, rpcAddr, serviceMethod string, args, reply any) error {
+	client, err := xc.dial(rpcAddr)
+	if err != nil {
+		return err
+	}
+	return client.Call(ctx, serviceMethod, args, reply)
+}
+
+// Call 从Discovery选出一个服务地址并发起调用
+func (xc *XClient) Call(ctx context.Context, serviceMethod string, args, reply any) error {
+	rpcAddr, err := xc.d.Get(xc.mode)
+	if err != nil {
+		return err
+	}
+	return xc.call(ctx, rpcAddr, serviceMethod, args, reply)
+}
+
+// Go 异步rpc调用
+// 从Discovery选出一个服务地址、建立(或复用)连接后,委托给底层simrpc.Client的异步路径
+// 如果在选址或建连阶段就失败了,返回一个已经带着err结束掉的Call
+func (xc *XClient) Go(serviceMethod string, args, reply any, done chan *simrpc.Call) *simrpc.Call {
+	if done != nil && cap(done) == 0 {
+		log.Panic("rpc client: done is a unbuffered channel\n")
+	}
+
+	rpcAddr, err := xc.d.Get(xc.mode)
+	if err != nil {
+		return newErrorCall(serviceMethod, args, reply, done, err)
+	}
+
+	client, err := xc.dial(rpcAddr)
+	if err != nil {
+		return newErrorCall(serviceMethod, args, reply, done, err)
+	}
+
+	return client.Go(serviceMethod, args, reply, done)
+}
+
+// newErrorCall 构造一个已经结束且带着err的Call,供Go在选址/建连阶段就失败时使用
+func newErrorCall(serviceMethod string, args, reply any, done chan *simrpc.Call, err error) *simrpc.Call {
+	if done == nil {
+		done = make(chan *simrpc.Call, 1)
+	}
+	call := &simrpc.Call{
+		ServiceMethod: serviceMethod,
+		Args:          args,
+		Reply:         reply,
+		Done:          done,
+		Error:         err,
+	}
+	call.Done <- call
+	return call
+}
+
+// Broadcast 向所有已知服务地址发起调用
+// 任意一个成功就返回其结果;全部失败则返回聚合后的错误
+// 一旦有调用失败就会取消ctx,让尚未返回的兄弟调用尽快放弃
+func (xc *XClient) Broadcast(ctx context.Context, serviceMethod string, args, reply any) error {
+	servers, err := xc.d.GetAll()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex // 保护errs、succeeded和replyDone
+	var wg sync.WaitGroup
+	var errs []error
+	succeeded := false
+	replyDone := reply == nil // reply为nil表示调用方不关心返回值,不需要反射赋值
+
+	for _, rpcAddr := range servers {
+		wg.Add(1)
+		go func(rpcAddr string) {
+			defer wg.Done()
+
+			var cloneReply any
+			if reply != nil {
+				cloneReply = reflect.New(reflect.ValueOf(reply).Elem().Type()).Interface()
+			}
+
+			callErr := xc.call(ctx, rpcAddr, serviceMethod, args, cloneReply)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if callErr != nil {
+				if len(errs) == 0 {
+					cancel() // 任意一个出错就取消其他还没返回的调用
+				}
+				errs = append(errs, callErr)
+				return
+			}
+			succeeded = true
+			if !replyDone {
+				reflect.ValueOf(reply).Elem().Set(reflect.ValueOf(cloneReply).Elem())
+				replyDone = true
+			}
+		}(rpcAddr)
+	}
+
+	wg.Wait()
+	if succeeded {
+		return nil
+	}
+	return errors.Join(errs...)
+}